@@ -0,0 +1,74 @@
+package flags
+
+// closest finds the candidate string closest to target by Levenshtein edit
+// distance (insert/delete/substitute each cost 1). ok is false when the
+// best candidate is still farther than max(2, len(target)/3) edits away,
+// in which case no suggestion is close enough to be worth showing.
+func closest(target string, candidates []string) (best string, distance int, ok bool) {
+	threshold := len(target) / 3
+
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	distance = threshold + 1
+
+	for _, candidate := range candidates {
+		d := levenshteinDistance(target, candidate)
+
+		if d < distance {
+			distance = d
+			best = candidate
+		}
+	}
+
+	if distance > threshold {
+		return "", 0, false
+	}
+
+	return best, distance, true
+}
+
+// levenshteinDistance computes the edit distance between a and b using the
+// standard dynamic programming recurrence, with a single rolling row to
+// keep it O(len(b)) in space.
+func levenshteinDistance(a string, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	row := make([]int, len(rb)+1)
+
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		prev := row[0]
+		row[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cur := row[j]
+
+			cost := 1
+
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			min := row[j] + 1
+
+			if row[j-1]+1 < min {
+				min = row[j-1] + 1
+			}
+
+			if prev+cost < min {
+				min = prev + cost
+			}
+
+			row[j] = min
+			prev = cur
+		}
+	}
+
+	return row[len(rb)]
+}
@@ -0,0 +1,79 @@
+package flags
+
+import "testing"
+
+func TestLevenshteinDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"verbose", "vebose", 1},
+		{"verbose", "verbos", 1},
+		{"kitten", "sitting", 3},
+		{"café", "cafe", 1},
+	}
+
+	for _, c := range cases {
+		if got := levenshteinDistance(c.a, c.b); got != c.want {
+			t.Errorf("levenshteinDistance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestLevenshteinDistanceSymmetric(t *testing.T) {
+	a, b := "flags", "flag"
+
+	if got, want := levenshteinDistance(a, b), levenshteinDistance(b, a); got != want {
+		t.Errorf("levenshteinDistance(%q, %q) = %d, levenshteinDistance(%q, %q) = %d, want equal", a, b, got, b, a, want)
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"verbose", "version", "help"}
+
+	best, distance, ok := closest("vebose", candidates)
+
+	if !ok {
+		t.Fatalf("closest(%q, %v) returned ok=false, want true", "vebose", candidates)
+	}
+
+	if best != "verbose" {
+		t.Errorf("closest(%q, %v) = %q, want %q", "vebose", candidates, best, "verbose")
+	}
+
+	if distance != 1 {
+		t.Errorf("closest(%q, %v) distance = %d, want 1", "vebose", candidates, distance)
+	}
+}
+
+func TestClosestNoCandidates(t *testing.T) {
+	if _, _, ok := closest("anything", nil); ok {
+		t.Errorf("closest with no candidates returned ok=true, want false")
+	}
+}
+
+func TestClosestBeyondThreshold(t *testing.T) {
+	// "xyz" has length 3, so the threshold is max(2, 3/3) = 2.
+	// "completely-different" is far more than 2 edits away and should not
+	// be suggested.
+	if _, _, ok := closest("xyz", []string{"completely-different"}); ok {
+		t.Errorf("closest(%q, ...) returned ok=true for a candidate far beyond the threshold", "xyz")
+	}
+}
+
+func TestClosestThresholdBoundary(t *testing.T) {
+	// "verbose" has length 7, so the threshold is max(2, 7/3) = 2.
+	// "verbos" is 1 edit away (within threshold); "verb" is 3 edits away
+	// (beyond threshold).
+	if _, _, ok := closest("verbose", []string{"verbos"}); !ok {
+		t.Errorf("closest(%q, %v) = ok=false, want true (within threshold)", "verbose", []string{"verbos"})
+	}
+
+	if _, _, ok := closest("verbose", []string{"verb"}); ok {
+		t.Errorf("closest(%q, %v) = ok=true, want false (beyond threshold)", "verbose", []string{"verb"})
+	}
+}
@@ -0,0 +1,209 @@
+package flags
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Commander is implemented by the data container passed to NewCommand or
+// AddCommand when the command itself has work to do once its options (and
+// those of every enclosing command) have been parsed. Execute receives the
+// non-option arguments that were left over after parsing.
+type Commander interface {
+	Execute(args []string) error
+}
+
+// Command represents a named (sub)command: a Group of options together
+// with a description and, optionally, further nested Commands. A Command
+// embeds a *Group so that its LongNames, ShortNames, Options and Error are
+// directly available.
+type Command struct {
+	*Group
+
+	// The name used to invoke this command on the command line.
+	Name string
+
+	// A one-line summary of the command, shown next to its name when the
+	// list of available subcommands is printed.
+	ShortDescription string
+
+	// A longer description of the command, shown in its own help text.
+	LongDescription string
+
+	// The command this command was added to, or nil for the root command.
+	Parent *Command
+
+	// The subcommands that were added with AddCommand, in the order they
+	// were added.
+	Commands []*Command
+
+	data interface{}
+}
+
+// NewCommand creates a new Command with the given name, descriptions and
+// underlying option struct. data follows the same rules as the data passed
+// to NewGroup, and any field of data tagged `command:"name"` is scanned and
+// added as a nested subcommand automatically.
+func NewCommand(name string, shortDescription string, longDescription string, data interface{}) *Command {
+	c := &Command{
+		Group:            NewGroup(name, data),
+		Name:             name,
+		ShortDescription: shortDescription,
+		LongDescription:  longDescription,
+		data:             data,
+	}
+
+	if c.Error == nil {
+		c.Error = c.scanCommands()
+	}
+
+	return c
+}
+
+// Find returns the direct subcommand of c with the given name.
+func (c *Command) Find(name string) (*Command, bool) {
+	for _, cmd := range c.Commands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+
+	return nil, false
+}
+
+// AddCommand creates a new Command as described by NewCommand, registers it
+// as a subcommand of c and returns it. An error is returned if data is not
+// a pointer to struct, or if name is already registered as a subcommand
+// of c.
+func (c *Command) AddCommand(name string, shortDescription string, longDescription string, data interface{}) (*Command, error) {
+	if _, ok := c.Find(name); ok {
+		return nil, newError(ErrCommandAlreadyExists, fmt.Sprintf("command `%s` already exists", name))
+	}
+
+	cmd := NewCommand(name, shortDescription, longDescription, data)
+
+	if cmd.Error != nil {
+		return nil, cmd.Error
+	}
+
+	cmd.Parent = c
+	c.Commands = append(c.Commands, cmd)
+
+	// A Command's options live on its embedded *Group, named after the
+	// command itself, so registering it as a child Group as well lets a
+	// [section] in an ini file (or a "did you mean" suggestion) resolve
+	// into a subcommand's options the same way it resolves into a
+	// group-tagged field's.
+	c.Groups = append(c.Groups, cmd.Group)
+
+	return cmd, nil
+}
+
+// scanCommands walks the fields of c.data looking for the `command` tag
+// and registers a nested subcommand for each one it finds, so that a
+// single struct can declare its whole command tree up front.
+func (c *Command) scanCommands() error {
+	ptrval := reflect.ValueOf(c.data)
+
+	if ptrval.Type().Kind() != reflect.Ptr {
+		return nil
+	}
+
+	stype := ptrval.Type().Elem()
+
+	if stype.Kind() != reflect.Struct {
+		return nil
+	}
+
+	realval := reflect.Indirect(ptrval)
+
+	for i := 0; i < stype.NumField(); i++ {
+		field := stype.Field(i)
+
+		name := field.Tag.Get("command")
+
+		if name == "" {
+			continue
+		}
+
+		fieldval := realval.Field(i)
+
+		if fieldval.Kind() == reflect.Ptr && fieldval.IsNil() {
+			fieldval.Set(reflect.New(fieldval.Type().Elem()))
+		}
+
+		if _, err := c.AddCommand(name, field.Tag.Get("description"), field.Tag.Get("long-description"), fieldval.Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Execute finalizes c's own options (environment fallbacks applied,
+// required options checked) and then walks args against c's subcommand
+// tree: as long as the next argument names a registered subcommand, it is
+// consumed and the active command switches to it, finalizing each command
+// along the way so that no enclosing command's required/env options are
+// skipped just because a subcommand ran. Once no further subcommand
+// matches, the remaining arguments are handed to the active command's
+// data via Commander.Execute, if it implements that interface.
+func (c *Command) Execute(args []string) error {
+	// c.Groups also holds the Group of every subcommand (see AddCommand);
+	// those are left out here and finalized only when args select them.
+	own := *c.Group
+	own.Groups = nil
+
+	for _, child := range c.Groups {
+		isCommand := false
+
+		for _, cmd := range c.Commands {
+			isCommand = isCommand || cmd.Group == child
+		}
+
+		if !isCommand {
+			own.Groups = append(own.Groups, child)
+		}
+	}
+
+	if err := own.Finalize(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		if sub, ok := c.Find(args[0]); ok {
+			return sub.Execute(args[1:])
+		}
+
+		if _, ok := c.data.(Commander); !ok && len(c.Commands) > 0 {
+			return c.unknownCommandError(args[0])
+		}
+	}
+
+	if cmdr, ok := c.data.(Commander); ok {
+		return cmdr.Execute(args)
+	}
+
+	if len(c.Commands) > 0 {
+		return newError(ErrUnknownCommand, "no command specified")
+	}
+
+	return nil
+}
+
+// unknownCommandError builds the *Error returned when name does not match
+// any subcommand of c, suggesting the closest registered subcommand name
+// when one is found within the edit-distance threshold.
+func (c *Command) unknownCommandError(name string) *Error {
+	names := make([]string, 0, len(c.Commands))
+
+	for _, cmd := range c.Commands {
+		names = append(names, cmd.Name)
+	}
+
+	if suggestion, _, ok := closest(name, names); ok {
+		return newError(ErrUnknownCommand, fmt.Sprintf("unknown command `%s`, did you mean `%s`?", name, suggestion))
+	}
+
+	return newError(ErrUnknownCommand, fmt.Sprintf("unknown command `%s`", name))
+}
@@ -0,0 +1,89 @@
+package flags
+
+// ErrorType represents the kind of problem an *Error describes, so that
+// callers can switch on it instead of comparing errors by value.
+type ErrorType uint
+
+const (
+	// ErrUnknown indicates a generic, uncategorized error.
+	ErrUnknown ErrorType = iota
+
+	// ErrNotPointerToStruct indicates that a data container passed to
+	// NewGroup (or NewCommand) was not a pointer to a struct.
+	ErrNotPointerToStruct
+
+	// ErrShortNameTooLong indicates a `short` tag longer than a single
+	// character.
+	ErrShortNameTooLong
+
+	// ErrUnknownFlag indicates a long or short option name, on the
+	// command line or in an ini file, that does not match any option in
+	// the Group.
+	ErrUnknownFlag
+
+	// ErrExpectedArgument indicates an option that requires an argument
+	// was given none.
+	ErrExpectedArgument
+
+	// ErrInvalidChoice indicates a value that does not match any of an
+	// option's allowed choices.
+	ErrInvalidChoice
+
+	// ErrRequired indicates that an option tagged `required:"true"` was
+	// never set.
+	ErrRequired
+
+	// ErrMarshal indicates a value could not be converted to its
+	// option's type.
+	ErrMarshal
+
+	// ErrUnknownCommand indicates a subcommand name, on the command
+	// line, that does not match any Command registered with AddCommand.
+	ErrUnknownCommand
+
+	// ErrCommandAlreadyExists indicates AddCommand was called with a
+	// name that is already registered as a subcommand.
+	ErrCommandAlreadyExists
+
+	// ErrIO indicates a failure reading or writing an ini file.
+	ErrIO
+)
+
+// Error is returned by this package in place of bare sentinel errors, so
+// that callers can switch on Type rather than comparing errors by value -
+// for example to exit with status 2 on a usage error versus 1 on a
+// runtime one.
+type Error struct {
+	// The category of problem that occurred.
+	Type ErrorType
+
+	// A human readable description of the error.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// newError creates a new *Error of type t with the given message.
+func newError(t ErrorType, message string) *Error {
+	return &Error{
+		Type:    t,
+		Message: message,
+	}
+}
+
+// WrapError wraps err as an *Error of type t, preserving its message. If
+// err is already an *Error, it is returned unchanged.
+func WrapError(err error, t ErrorType) *Error {
+	if err == nil {
+		return nil
+	}
+
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+
+	return newError(t, err.Error())
+}
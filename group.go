@@ -1,18 +1,13 @@
 package flags
 
 import (
-	"errors"
 	"fmt"
+	"os"
 	"reflect"
+	"strings"
 	"unicode/utf8"
 )
 
-// The provided container is not a pointer to a struct
-var ErrNotPointerToStruct = errors.New("provided data is not a pointer to struct")
-
-// The provided short name is longer than a single character
-var ErrShortNameTooLong = errors.New("short names can only be 1 character")
-
 // Option flag information. Contains a description of the option, short and
 // long name as well as a default value and whether an argument for this
 // flag is optional.
@@ -44,8 +39,19 @@ type Info struct {
 	// This is only valid for non-boolean options.
 	OptionalArgument bool
 
+	// The environment variables, taken from the comma-separated `env`
+	// tag, that are tried in order as a fallback value when the option is
+	// never set on the command line.
+	EnvNames []string
+
+	// If true, parsing fails with an ErrRequired error unless the option
+	// is set, either on the command line or, failing that, from one of
+	// EnvNames.
+	Required bool
+
 	value   reflect.Value
 	options reflect.StructTag
+	isSet   bool
 }
 
 // An option group. The option group has a name and a set of options.
@@ -62,6 +68,13 @@ type Group struct {
 	// A list of all the options in the group.
 	Options    []*Info
 
+	// Child groups, either added directly or produced by scanning a
+	// field tagged `group:"name"`. A child group's own options are kept
+	// separate from its parent's LongNames/ShortNames; use
+	// LookupLongName/LookupShortName to search a Group and all of its
+	// descendants at once.
+	Groups []*Group
+
 	// An error which occurred when creating the group.
 	Error error
 
@@ -108,21 +121,42 @@ func (info *Info) call(value *string) {
 	}
 }
 
-// Set the value of an option to the specified value. An error will be returned
-// if the specified value could not be converted to the corresponding option
-// value type.
+// Set the value of an option to the specified value. A *Error of type
+// ErrMarshal is returned if the specified value could not be converted to
+// the corresponding option value type.
 func (info *Info) Set(value *string) error {
 	if info.isFunc() {
 		info.call(value)
 	} else if value != nil {
-		return convert(*value, info.value, info.options)
-	} else {
-		return convert("", info.value, info.options)
+		if err := convert(*value, info.value, info.options); err != nil {
+			return WrapError(err, ErrMarshal)
+		}
+	} else if err := convert("", info.value, info.options); err != nil {
+		return WrapError(err, ErrMarshal)
 	}
 
+	info.isSet = true
 	return nil
 }
 
+// IsSet reports whether the option's value was ever successfully set,
+// whether on the command line, from an ini file or from an environment
+// variable.
+func (info *Info) IsSet() bool {
+	return info.isSet
+}
+
+// StringValue formats the option's current field value as a string,
+// suitable for writing back out (e.g. to an ini file). Func options, which
+// have no stored value, format as "".
+func (info *Info) StringValue() string {
+	if info.isFunc() {
+		return ""
+	}
+
+	return fmt.Sprintf("%v", info.value.Interface())
+}
+
 // Convert an option to a human friendly readable string describing the option.
 func (info *Info) String() string {
 	var s string
@@ -166,22 +200,154 @@ func NewGroup(name string, data interface{}) *Group {
 	return ret
 }
 
+// LookupLongName searches g, and then each of g.Groups in turn, for an
+// option with the given long name.
+func (g *Group) LookupLongName(name string) (*Info, bool) {
+	if info, ok := g.LongNames[name]; ok {
+		return info, true
+	}
+
+	for _, child := range g.Groups {
+		if info, ok := child.LookupLongName(name); ok {
+			return info, true
+		}
+	}
+
+	return nil, false
+}
+
+// LookupShortName searches g, and then each of g.Groups in turn, for an
+// option with the given short name.
+func (g *Group) LookupShortName(name rune) (*Info, bool) {
+	if info, ok := g.ShortNames[name]; ok {
+		return info, true
+	}
+
+	for _, child := range g.Groups {
+		if info, ok := child.LookupShortName(name); ok {
+			return info, true
+		}
+	}
+
+	return nil, false
+}
+
+// allLongNames collects the long names of every option in g and all of
+// its descendant Groups, for use as candidates in closest().
+func (g *Group) allLongNames() []string {
+	names := make([]string, 0, len(g.LongNames))
+
+	for name := range g.LongNames {
+		names = append(names, name)
+	}
+
+	for _, child := range g.Groups {
+		names = append(names, child.allLongNames()...)
+	}
+
+	return names
+}
+
+// ApplyEnv fills in, from their EnvNames in order, the value of every
+// option in g and all of its descendant Groups that was not set during
+// command-line (or ini) parsing. It should be called once parsing has
+// otherwise finished, so that the command line always takes precedence
+// over the environment.
+func (g *Group) ApplyEnv() error {
+	for _, info := range g.Options {
+		if info.isSet {
+			continue
+		}
+
+		for _, name := range info.EnvNames {
+			value, ok := os.LookupEnv(name)
+
+			if !ok || value == "" {
+				continue
+			}
+
+			if err := info.Set(&value); err != nil {
+				return WrapError(err, ErrMarshal)
+			}
+
+			break
+		}
+	}
+
+	for _, child := range g.Groups {
+		if err := child.ApplyEnv(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckRequired returns an ErrRequired *Error naming every option tagged
+// `required:"true"`, in g or any of its descendant Groups, that is still
+// unset, or nil if all of them were set. It should be called once parsing
+// (including ApplyEnv) has finished.
+func (g *Group) CheckRequired() error {
+	missing := g.missingRequired()
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return newError(ErrRequired, fmt.Sprintf("the required flag(s) %s were not specified", strings.Join(missing, ", ")))
+}
+
+// missingRequired collects the String() of every required, unset option in
+// g and all of its descendant Groups.
+func (g *Group) missingRequired() []string {
+	var missing []string
+
+	for _, info := range g.Options {
+		if info.Required && !info.isSet {
+			missing = append(missing, info.String())
+		}
+	}
+
+	for _, child := range g.Groups {
+		missing = append(missing, child.missingRequired()...)
+	}
+
+	return missing
+}
+
+// Finalize applies environment variable fallbacks and then checks that
+// every required option was set, in that order, so that an environment
+// variable can satisfy a `required` option. It is meant to be called once
+// after all other parsing (command line, ini file, ...) has completed.
+func (g *Group) Finalize() error {
+	if err := g.ApplyEnv(); err != nil {
+		return err
+	}
+
+	return g.CheckRequired()
+}
+
 func (g *Group) scan() error {
 	// Get all the public fields in the data struct
 	ptrval := reflect.ValueOf(g.data)
 
 	if ptrval.Type().Kind() != reflect.Ptr {
-		return ErrNotPointerToStruct
+		return newError(ErrNotPointerToStruct, "provided data is not a pointer to struct")
 	}
 
 	stype := ptrval.Type().Elem()
 
 	if stype.Kind() != reflect.Struct {
-		return ErrNotPointerToStruct
+		return newError(ErrNotPointerToStruct, "provided data is not a pointer to struct")
 	}
 
-	realval := reflect.Indirect(ptrval)
+	return g.scanStruct(stype, reflect.Indirect(ptrval))
+}
 
+// scanStruct scans the fields of the struct described by stype/realval into
+// g, recursing into anonymous (embedded) fields and into fields tagged
+// `group:"name"`.
+func (g *Group) scanStruct(stype reflect.Type, realval reflect.Value) error {
 	for i := 0; i < stype.NumField(); i++ {
 		field := stype.Field(i)
 
@@ -190,13 +356,26 @@ func (g *Group) scan() error {
 			continue
 		}
 
-		// Skip anonymous fields
+		// Skip fields with the no-flag tag
+		if field.Tag.Get("no-flag") != "" {
+			continue
+		}
+
+		// Recursively merge anonymous (embedded) structs into this group
 		if field.Anonymous {
+			if err := g.scanEmbedded(field, realval.Field(i)); err != nil {
+				return err
+			}
+
 			continue
 		}
 
-		// Skip fields with the no-flag tag
-		if field.Tag.Get("no-flag") != "" {
+		// Fields tagged `group:"name"` become a nested child Group
+		if groupName := field.Tag.Get("group"); groupName != "" {
+			if err := g.scanGroup(groupName, field, realval.Field(i)); err != nil {
+				return err
+			}
+
 			continue
 		}
 
@@ -211,7 +390,7 @@ func (g *Group) scan() error {
 		rc := utf8.RuneCountInString(shortname)
 
 		if rc > 1 {
-			return ErrShortNameTooLong
+			return newError(ErrShortNameTooLong, "short names can only be 1 character")
 		} else if rc == 1 {
 			short, _ = utf8.DecodeRuneInString(shortname)
 		}
@@ -220,6 +399,15 @@ func (g *Group) scan() error {
 		def := field.Tag.Get("default")
 
 		optional := (field.Tag.Get("optional") != "")
+		required := (field.Tag.Get("required") != "")
+
+		var envNames []string
+
+		if env := field.Tag.Get("env"); env != "" {
+			for _, name := range strings.Split(env, ",") {
+				envNames = append(envNames, strings.TrimSpace(name))
+			}
+		}
 
 		info := &Info{
 			Description:      description,
@@ -227,6 +415,8 @@ func (g *Group) scan() error {
 			LongName:         longname,
 			Default:          def,
 			OptionalArgument: optional,
+			EnvNames:         envNames,
+			Required:         required,
 			value:            realval.Field(i),
 			options:          field.Tag,
 		}
@@ -244,3 +434,72 @@ func (g *Group) scan() error {
 
 	return nil
 }
+
+// scanEmbedded recursively scans an anonymous struct field, merging its
+// options directly into the enclosing group as if they had been declared
+// on it. A nil embedded pointer is allocated so its fields are addressable.
+func (g *Group) scanEmbedded(field reflect.StructField, fieldval reflect.Value) error {
+	etype := field.Type
+
+	if etype.Kind() == reflect.Ptr {
+		etype = etype.Elem()
+
+		if fieldval.IsNil() {
+			fieldval.Set(reflect.New(etype))
+		}
+
+		fieldval = reflect.Indirect(fieldval)
+	}
+
+	if etype.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return g.scanStruct(etype, fieldval)
+}
+
+// scanGroup scans a field tagged `group:"name"` into a new child Group
+// appended to g.Groups. When the field also carries a `namespace:"pfx"`
+// tag, every long name in the child group is rewritten to "pfx<sep>name",
+// where <sep> is "." unless overridden with `namespace-sep:"..."`.
+func (g *Group) scanGroup(name string, field reflect.StructField, fieldval reflect.Value) error {
+	var data interface{}
+
+	if fieldval.Kind() == reflect.Ptr {
+		if fieldval.IsNil() {
+			fieldval.Set(reflect.New(fieldval.Type().Elem()))
+		}
+
+		data = fieldval.Interface()
+	} else {
+		data = fieldval.Addr().Interface()
+	}
+
+	child := NewGroup(name, data)
+
+	if child.Error != nil {
+		return child.Error
+	}
+
+	if namespace := field.Tag.Get("namespace"); namespace != "" {
+		sep := field.Tag.Get("namespace-sep")
+
+		if sep == "" {
+			sep = "."
+		}
+
+		for _, info := range child.Options {
+			if info.LongName == "" {
+				continue
+			}
+
+			delete(child.LongNames, info.LongName)
+			info.LongName = namespace + sep + info.LongName
+			child.LongNames[info.LongName] = info
+		}
+	}
+
+	g.Groups = append(g.Groups, child)
+
+	return nil
+}
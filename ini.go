@@ -0,0 +1,291 @@
+package flags
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// IniError occurs when a line of an ini file cannot be applied to the bound
+// Group: an unknown section or option, a malformed line, or a value that
+// could not be converted. It carries the file and line number at which the
+// problem was found so that callers can produce a useful diagnostic. A file
+// that could not be opened or read yields an *Error of type ErrIO instead.
+type IniError struct {
+	// The category of problem that occurred.
+	Type ErrorType
+
+	// The underlying error.
+	Message string
+
+	// The name of the ini file, if any.
+	File string
+
+	// The line number at which the error occurred.
+	LineNumber int
+}
+
+func (e *IniError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.LineNumber, e.Message)
+	}
+
+	return e.Message
+}
+
+// IniOptions control the behavior of IniParser.Write/WriteFile.
+type IniOptions uint
+
+// IniNone writes only the options that were explicitly set.
+const IniNone IniOptions = 0
+
+const (
+	// IniIncludeDefaults writes every option, not just the ones that were
+	// explicitly set, using their Default value when unset.
+	IniIncludeDefaults IniOptions = 1 << iota
+
+	// IniCommentDefaults comments out (prefixes with `;`) any option that
+	// is being written purely because of IniIncludeDefaults.
+	IniCommentDefaults
+
+	// IniIncludeComments writes each option's Description as a comment
+	// above the option, producing a self-documenting template.
+	IniIncludeComments
+)
+
+// IniParser binds a Group to an ini-formatted configuration file: reading
+// populates the Group's options via Info.Set, and writing emits the
+// Group's options as `key = value` pairs.
+type IniParser struct {
+	Group *Group
+}
+
+// NewIniParser creates a new IniParser that reads and writes the options
+// of g.
+func NewIniParser(g *Group) *IniParser {
+	return &IniParser{Group: g}
+}
+
+// groupByName looks up a (possibly root) section name, descending into
+// nested groups (added directly, via a `group` tag, or as subcommands)
+// when the name matches one of them.
+func (i *IniParser) groupByName(name string) (*Group, bool) {
+	return findGroupByName(i.Group, name)
+}
+
+// findGroupByName returns g itself if name is empty or matches g.Name,
+// otherwise it searches g.Groups recursively.
+func findGroupByName(g *Group, name string) (*Group, bool) {
+	if name == "" || name == g.Name {
+		return g, true
+	}
+
+	for _, child := range g.Groups {
+		if found, ok := findGroupByName(child, name); ok {
+			return found, true
+		}
+	}
+
+	return nil, false
+}
+
+// sectionNames collects the name of every group in g's tree, excluding g
+// itself, for use as candidates when suggesting a mistyped [section].
+func sectionNames(g *Group) []string {
+	var names []string
+
+	for _, child := range g.Groups {
+		names = append(names, child.Name)
+		names = append(names, sectionNames(child)...)
+	}
+
+	return names
+}
+
+// Parse reads ini-formatted data from r and applies it to the bound Group.
+// Lines starting with `;` or `#` are treated as comments. A `[section]`
+// line switches which (sub)group subsequent `key = value` lines apply to.
+// An unknown key produces an *IniError.
+func (i *IniParser) Parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+
+	group := i.Group
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+
+			g, ok := i.groupByName(name)
+
+			if !ok {
+				message := fmt.Sprintf("unknown section `%s`", name)
+
+				if suggestion, _, ok := closest(name, sectionNames(i.Group)); ok {
+					message = fmt.Sprintf("unknown section `%s`, did you mean `%s`?", name, suggestion)
+				}
+
+				return &IniError{
+					Type:       ErrUnknownFlag,
+					Message:    message,
+					LineNumber: lineNumber,
+				}
+			}
+
+			group = g
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+
+		if len(parts) != 2 {
+			return &IniError{
+				Type:       ErrIO,
+				Message:    fmt.Sprintf("malformed ini line `%s`", line),
+				LineNumber: lineNumber,
+			}
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		info, ok := group.LongNames[key]
+
+		if !ok && utf8.RuneCountInString(key) == 1 {
+			r, _ := utf8.DecodeRuneInString(key)
+			info, ok = group.ShortNames[r]
+		}
+
+		if !ok {
+			message := fmt.Sprintf("unknown option `%s`", key)
+
+			if suggestion, _, ok := closest(key, group.allLongNames()); ok {
+				message = fmt.Sprintf("unknown option `%s`, did you mean `%s`?", key, suggestion)
+			}
+
+			return &IniError{
+				Type:       ErrUnknownFlag,
+				Message:    message,
+				LineNumber: lineNumber,
+			}
+		}
+
+		if err := info.Set(&value); err != nil {
+			return &IniError{
+				Type:       ErrMarshal,
+				Message:    err.Error(),
+				LineNumber: lineNumber,
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return WrapError(err, ErrIO)
+	}
+
+	return nil
+}
+
+// ParseFile opens path and Parses its contents, annotating any returned
+// *IniError with the file name.
+func (i *IniParser) ParseFile(path string) error {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return WrapError(err, ErrIO)
+	}
+
+	defer f.Close()
+
+	if err := i.Parse(f); err != nil {
+		if inierr, ok := err.(*IniError); ok {
+			inierr.File = path
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Write writes the bound Group's options to w as ini-formatted text,
+// according to options.
+func (i *IniParser) Write(w io.Writer, options IniOptions) error {
+	return i.writeGroup(w, i.Group, options)
+}
+
+func (i *IniParser) writeGroup(w io.Writer, g *Group, options IniOptions) error {
+	for _, info := range g.Options {
+		if info.LongName == "" {
+			continue
+		}
+
+		written := info.IsSet()
+
+		if !written && options&IniIncludeDefaults == 0 {
+			continue
+		}
+
+		value := info.Default
+
+		if written {
+			value = info.StringValue()
+		}
+
+		if options&IniIncludeComments != 0 && info.Description != "" {
+			if _, err := fmt.Fprintf(w, "; %s\n", info.Description); err != nil {
+				return err
+			}
+		}
+
+		prefix := ""
+
+		if !written && options&IniCommentDefaults != 0 {
+			prefix = "; "
+		}
+
+		if _, err := fmt.Fprintf(w, "%s%s = %s\n", prefix, info.LongName, value); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range g.Groups {
+		if _, err := fmt.Fprintf(w, "\n[%s]\n", child.Name); err != nil {
+			return err
+		}
+
+		if err := i.writeGroup(w, child, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteFile writes the bound Group's options to the file at path.
+func (i *IniParser) WriteFile(path string, options IniOptions) error {
+	f, err := os.Create(path)
+
+	if err != nil {
+		return WrapError(err, ErrIO)
+	}
+
+	defer f.Close()
+
+	if err := i.Write(f, options); err != nil {
+		return WrapError(err, ErrIO)
+	}
+
+	return nil
+}